@@ -0,0 +1,226 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StorageProviderAzure identifies the Azure Blob Storage uploader in the storage.provider option
+const StorageProviderAzure = "azure"
+
+// Constants for the Azure Blob uploader 'start' operation options
+const (
+	AzureAccountProp   = "azure.account"
+	AzureContainerProp = "azure.container"
+	AzureBlobProp      = "azure.blob"
+	AzureSASTokenProp  = "azure.sasToken"
+	AzureBlockSizeProp = "azure.blockSize"
+
+	defaultAzureBlockSize = 4 * 1024 * 1024
+
+	azureAPIVersion = "2020-10-02"
+)
+
+type blockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+// AzureUploader uploads files to Azure Blob Storage as a sequence of staged blocks,
+// committed with a final block list PUT
+type AzureUploader struct {
+	account    string
+	container  string
+	blob       string
+	sasToken   string
+	blockSize  int64
+	serverCert string
+	tls        tlsOptions
+}
+
+// NewAzureUploader constructs an AzureUploader from the provided 'start' operation options
+func NewAzureUploader(options map[string]string, serverCert string) (Uploader, error) {
+	account := options[AzureAccountProp]
+	if account == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, AzureAccountProp)
+	}
+
+	container := options[AzureContainerProp]
+	if container == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, AzureContainerProp)
+	}
+
+	blob := options[AzureBlobProp]
+	if blob == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, AzureBlobProp)
+	}
+
+	blockSize := int64(defaultAzureBlockSize)
+	if v, ok := options[AzureBlockSizeProp]; ok {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s: %s", AzureBlockSizeProp, v)
+		}
+		blockSize = parsed
+	}
+
+	tlsOpts, err := parseTLSOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AzureUploader{
+		account:    account,
+		container:  container,
+		blob:       blob,
+		sasToken:   options[AzureSASTokenProp],
+		blockSize:  blockSize,
+		serverCert: serverCert,
+		tls:        tlsOpts,
+	}, nil
+}
+
+func (u *AzureUploader) httpClient() (*http.Client, error) {
+	transport, err := u.tls.buildTransport(u.serverCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+func (u *AzureUploader) blobURL() string {
+	blobURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", u.account, u.container, EscapePathSegments(u.blob))
+	if u.sasToken != "" {
+		blobURL = blobURL + "?" + strings.TrimPrefix(u.sasToken, "?")
+	}
+	return blobURL
+}
+
+func (u *AzureUploader) withQuery(query string) string {
+	return AppendQueryParams(u.blobURL(), query)
+}
+
+// UploadFile stages the file as blocks and commits them with a block list PUT
+func (u *AzureUploader) UploadFile(file *os.File, useChecksum bool, listener func(bytesTransferred int64)) error {
+	stats, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	client, err := u.httpClient()
+	if err != nil {
+		return err
+	}
+
+	var blockIDs []string
+	var transferred int64
+	blockIndex := 0
+	buf := make([]byte, u.blockSize)
+
+	for {
+		n, readErr := io.ReadFull(file, buf)
+		if n == 0 {
+			break
+		}
+
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", blockIndex)))
+		if err := u.stageBlock(client, blockID, buf[:n], useChecksum); err != nil {
+			return err
+		}
+		blockIDs = append(blockIDs, blockID)
+
+		transferred += int64(n)
+		if listener != nil {
+			listener(transferred)
+		}
+		blockIndex++
+
+		if readErr == io.ErrUnexpectedEOF || readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if transferred != stats.Size() {
+		return fmt.Errorf("uploaded %d of %d bytes", transferred, stats.Size())
+	}
+
+	return u.commitBlockList(client, blockIDs)
+}
+
+func (u *AzureUploader) stageBlock(client *http.Client, blockID string, block []byte, useChecksum bool) error {
+	url := u.withQuery(fmt.Sprintf("comp=block&blockid=%s", blockID))
+
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(string(block)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(block))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+
+	if useChecksum {
+		sum := md5.Sum(block)
+		req.Header.Set(ContentMD5, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("stage block failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
+
+func (u *AzureUploader) commitBlockList(client *http.Client, blockIDs []string) error {
+	body, err := xml.Marshal(blockList{Latest: blockIDs})
+	if err != nil {
+		return err
+	}
+
+	url := u.withQuery("comp=blocklist")
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("commit block list failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import "testing"
+
+func baseAzureOptions() map[string]string {
+	return map[string]string{
+		AzureAccountProp:   "account",
+		AzureContainerProp: "container",
+		AzureBlobProp:      "blob",
+	}
+}
+
+func TestNewAzureUploaderBlockSizeValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		blockSize string
+		wantErr   bool
+	}{
+		{name: "unset uses default", blockSize: ""},
+		{name: "positive value", blockSize: "1048576"},
+		{name: "zero is rejected", blockSize: "0", wantErr: true},
+		{name: "negative is rejected", blockSize: "-1", wantErr: true},
+		{name: "not a number is rejected", blockSize: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := baseAzureOptions()
+			if tt.blockSize != "" {
+				options[AzureBlockSizeProp] = tt.blockSize
+			}
+
+			_, err := NewAzureUploader(options, "")
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAzureBlobURLEscapesBlobNameAndKeepsSASToken(t *testing.T) {
+	options := baseAzureOptions()
+	options[AzureBlobProp] = "path/to/blob#1 two.bin"
+	options[AzureSASTokenProp] = "?sv=2020-10-02&sig=abc"
+
+	uploader, err := NewAzureUploader(options, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := uploader.(*AzureUploader).blobURL()
+	want := "https://account.blob.core.windows.net/container/path/to/blob%231%20two.bin?sv=2020-10-02&sig=abc"
+	if got != want {
+		t.Errorf("blobURL() = %q, want %q", got, want)
+	}
+}
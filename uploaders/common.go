@@ -12,29 +12,31 @@
 package uploaders
 
 import (
-	"crypto/md5"
 	"crypto/tls"
-	"crypto/x509"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
-
-	"github.com/eclipse-kanto/file-upload/logger"
 )
 
 // Constants for HTTP(S) file upload 'start' operation options
 const (
 	StorageProviderHTTP = "generic"
 
+	StorageProviderProp = "storage.provider"
+
 	URLProp       = "https.url"
 	MethodProp    = "https.method"
 	HeadersPrefix = "https.header."
+
+	MultipartPartSizeProp    = "https.multipart.partSize"
+	MultipartConcurrencyProp = "https.multipart.concurrency"
+	MultipartTrailerProp     = "https.multipart.trailer"
+
+	ResumeInitURLProp  = "https.resume.initURL"
+	ResumeStateDirProp = "https.resume.stateDir"
 )
 
 // ContentMD5 header name
@@ -49,11 +51,27 @@ type Uploader interface {
 
 // HTTPUploader handles generic HTTP uploads
 type HTTPUploader struct {
-	url          string
-	headers      map[string]string
-	method       string
-	serverCert   string
-	cipherSuites []uint16
+	url        string
+	headers    map[string]string
+	method     string
+	serverCert string
+	tls        tlsOptions
+
+	multipartPartSize    int64
+	multipartConcurrency int
+	multipartTrailer     bool
+
+	checksumAlgorithms []string
+
+	resumeInitURL  string
+	resumeStateDir string
+
+	awsSigV4           bool
+	awsAccessKey       string
+	awsSecretKey       string
+	awsRegion          string
+	awsService         string
+	awsUnsignedPayload bool
 }
 
 // NewHTTPUploader construct new HttpUploader from the provided 'start' operation options
@@ -76,33 +94,62 @@ func NewHTTPUploader(options map[string]string, serverCert string) (Uploader, er
 
 	headers := ExtractDictionary(options, HeadersPrefix)
 
-	return &HTTPUploader{url, headers, method, serverCert, SupportedCipherSuites()}, nil
-}
+	partSize, err := parseMultipartPartSize(options)
+	if err != nil {
+		return nil, err
+	}
 
-func (u *HTTPUploader) getHTTPTransport() (*http.Transport, error) {
-	var caCertPool *x509.CertPool
-	if len(u.serverCert) > 0 {
-		caCert, err := ioutil.ReadFile(u.serverCert)
-		if err != nil {
-			logger.Errorf("Error reading CA certificate file - \"%s\"", u.serverCert)
-			return nil, err
-		}
-		caCertPool = x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM(caCert)
+	concurrency, err := parseMultipartConcurrency(options)
+	if err != nil {
+		return nil, err
+	}
+
+	trailer := options[MultipartTrailerProp] == "true"
+
+	algorithms, err := ParseChecksumAlgorithms(options[ChecksumAlgorithmsProp])
+	if err != nil {
+		return nil, err
 	}
 
-	config := &tls.Config{ // using the system CA pool
-		InsecureSkipVerify: false,
-		RootCAs:            caCertPool,
-		MinVersion:         tls.VersionTLS12,
-		MaxVersion:         tls.VersionTLS13,
-		CipherSuites:       u.cipherSuites,
+	awsSigV4, accessKey, secretKey, region, service, unsignedPayload, err := parseAWSSigV4Options(options)
+	if err != nil {
+		return nil, err
 	}
-	return &http.Transport{
-		TLSClientConfig: config,
+
+	tlsOpts, err := parseTLSOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPUploader{
+		url:        url,
+		headers:    headers,
+		method:     method,
+		serverCert: serverCert,
+		tls:        tlsOpts,
+
+		multipartPartSize:    partSize,
+		multipartConcurrency: concurrency,
+		multipartTrailer:     trailer,
+
+		checksumAlgorithms: algorithms,
+
+		resumeInitURL:  options[ResumeInitURLProp],
+		resumeStateDir: options[ResumeStateDirProp],
+
+		awsSigV4:           awsSigV4,
+		awsAccessKey:       accessKey,
+		awsSecretKey:       secretKey,
+		awsRegion:          region,
+		awsService:         service,
+		awsUnsignedPayload: unsignedPayload,
 	}, nil
 }
 
+func (u *HTTPUploader) getHTTPTransport() (*http.Transport, error) {
+	return u.tls.buildTransport(u.serverCert)
+}
+
 // UploadFile performs generic HTTP file upload
 func (u *HTTPUploader) UploadFile(file *os.File, useChecksum bool, listener func(bytesTransferred int64)) error {
 	stats, err := file.Stat()
@@ -110,11 +157,6 @@ func (u *HTTPUploader) UploadFile(file *os.File, useChecksum bool, listener func
 		return err
 	}
 
-	req, err := http.NewRequest(u.method, u.url, file)
-	if err != nil {
-		return err
-	}
-
 	parsedURL, _ := url.Parse(u.url) // MUST not return error, since http(s) request was done to that url
 	transport := &http.Transport{}
 	if parsedURL.Scheme == "https" {
@@ -124,20 +166,60 @@ func (u *HTTPUploader) UploadFile(file *os.File, useChecksum bool, listener func
 		}
 	}
 
+	if u.resumeStateDir != "" {
+		client := &http.Client{Transport: transport}
+		return u.uploadResumable(client, file, stats.Size(), useChecksum, listener)
+	}
+
+	if u.multipartPartSize > 0 && stats.Size() > u.multipartPartSize {
+		client := &http.Client{Transport: transport}
+		return u.uploadMultipart(client, file, stats.Size(), useChecksum, listener)
+	}
+
+	req, err := http.NewRequest(u.method, u.url, file)
+	if err != nil {
+		return err
+	}
+
 	req.Header.Set("Content-Type", "application/x-binary")
 	for name, value := range u.headers {
 		req.Header.Set(name, value)
 	}
 
 	if useChecksum {
-		md5, err := ComputeMD5(file, true)
+		algorithms := u.checksumAlgorithms
+		if len(algorithms) == 0 {
+			algorithms = []string{AlgorithmMD5}
+		}
+
+		digests, err := ComputeDigests(file, algorithms)
 		if err != nil {
 			return err
 		}
-		req.Header.Set(ContentMD5, md5)
+
+		if md5, ok := digests[AlgorithmMD5]; ok {
+			req.Header.Set(ContentMD5, md5)
+		}
+		if header := BuildContentDigestHeader(digests, algorithms); header != "" {
+			req.Header.Set(ContentDigestHeader, header)
+		}
 	}
 
 	req.ContentLength = stats.Size()
+
+	if u.awsSigV4 {
+		payloadHash := unsignedPayload
+		if !u.awsUnsignedPayload {
+			payloadHash, err = sha256HexDigest(file)
+			if err != nil {
+				return err
+			}
+		}
+		if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+			return err
+		}
+	}
+
 	// Send the HTTP(S) request and get its response.
 	client := &http.Client{Transport: transport}
 	resp, err := client.Do(req)
@@ -155,6 +237,28 @@ func (u *HTTPUploader) UploadFile(file *os.File, useChecksum bool, listener func
 	return nil
 }
 
+// AppendQueryParams appends query to rawURL, joining with "&" when rawURL already carries a query
+// component and with "?" otherwise, so a generic https.url that already has query parameters
+// (e.g. a pre-signed or tokenized URL) isn't corrupted by a second "?".
+func AppendQueryParams(rawURL, query string) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + query
+}
+
+// EscapePathSegments percent-escapes each '/'-separated segment of path independently, leaving
+// the separators themselves intact, so an object key or blob name containing characters like "#"
+// or "?" stays a single path segment instead of truncating the URL or swallowing its query string.
+func EscapePathSegments(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
 // ExtractDictionary extracts from the given map properties with a specified prefix.
 // In the resulting dictionary, property names have the prefix removed.
 func ExtractDictionary(options map[string]string, prefix string) map[string]string {
@@ -171,25 +275,6 @@ func ExtractDictionary(options map[string]string, prefix string) map[string]stri
 	return info
 }
 
-// ComputeMD5 returns the MD5 hash of a file, which can be encoded as base64 string.
-func ComputeMD5(f *os.File, encodeBase64 bool) (string, error) {
-	h := md5.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-
-	md5 := h.Sum(nil)
-
-	f.Seek(0, 0)
-
-	if !encodeBase64 {
-		return string(md5), nil
-	}
-	encoded := base64.StdEncoding.EncodeToString(md5)
-
-	return encoded, nil
-}
-
 // SupportedCipherSuites returns the ids of secure TLS cipher suites
 func SupportedCipherSuites() []uint16 {
 	cs := tls.CipherSuites()
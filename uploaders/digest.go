@@ -0,0 +1,158 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+)
+
+// Digest algorithm names accepted by the https.checksum.algorithms option
+const (
+	AlgorithmMD5    = "md5"
+	AlgorithmSHA256 = "sha256"
+	AlgorithmCRC32C = "crc32c"
+)
+
+// ChecksumAlgorithmsProp is the 'start' operation option listing the digest algorithms to
+// compute for the uploaded file, e.g. "md5,sha256,crc32c"
+const ChecksumAlgorithmsProp = "https.checksum.algorithms"
+
+// ContentDigestHeader is the RFC 9530 header carrying one or more structured content digests
+const ContentDigestHeader = "Content-Digest"
+
+// digestFieldNames maps an algorithm name to its RFC 9530 structured-field dictionary key
+var digestFieldNames = map[string]string{
+	AlgorithmMD5:    "md5",
+	AlgorithmSHA256: "sha-256",
+	AlgorithmCRC32C: "crc32c",
+}
+
+func newDigestHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmMD5:
+		return md5.New(), nil
+	case AlgorithmSHA256:
+		return sha256.New(), nil
+	case AlgorithmCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+}
+
+// ParseChecksumAlgorithms splits and validates the value of the https.checksum.algorithms option
+func ParseChecksumAlgorithms(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var algorithms []string
+	for _, algo := range strings.Split(value, ",") {
+		algo = strings.TrimSpace(algo)
+		if algo == "" {
+			continue
+		}
+		if _, err := newDigestHash(algo); err != nil {
+			return nil, err
+		}
+		algorithms = append(algorithms, algo)
+	}
+
+	return algorithms, nil
+}
+
+// ComputeDigests streams the file once through the given digest algorithms, returning a map of
+// algorithm to its base64-encoded digest. The file offset is reset to the start afterwards.
+func ComputeDigests(f *os.File, algorithms []string) (map[string]string, error) {
+	hashes := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+
+	for _, algo := range algorithms {
+		h, err := newDigestHash(algo)
+		if err != nil {
+			return nil, err
+		}
+		hashes[algo] = h
+		writers = append(writers, h)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, err
+	}
+	f.Seek(0, 0)
+
+	digests := make(map[string]string, len(hashes))
+	for algo, h := range hashes {
+		digests[algo] = base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+
+	return digests, nil
+}
+
+// BuildContentDigestHeader renders the given digests as an RFC 9530 Content-Digest header value,
+// in the order the algorithms were requested.
+func BuildContentDigestHeader(digests map[string]string, algorithms []string) string {
+	var entries []string
+	for _, algo := range algorithms {
+		digest, ok := digests[algo]
+		if !ok {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s=:%s:", digestFieldNames[algo], digest))
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+// ComputeDigestsBytes hashes data with the given digest algorithms, returning a map of algorithm
+// to its base64-encoded digest. Used for in-memory chunks (multipart parts, resumable upload
+// ranges) where ComputeDigests' *os.File based streaming doesn't apply.
+func ComputeDigestsBytes(data []byte, algorithms []string) (map[string]string, error) {
+	digests := make(map[string]string, len(algorithms))
+
+	for _, algo := range algorithms {
+		h, err := newDigestHash(algo)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(data)
+		digests[algo] = base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+
+	return digests, nil
+}
+
+// ComputeMD5 returns the MD5 hash of a file, which can be encoded as base64 string.
+func ComputeMD5(f *os.File, encodeBase64 bool) (string, error) {
+	digests, err := ComputeDigests(f, []string{AlgorithmMD5})
+	if err != nil {
+		return "", err
+	}
+
+	if !encodeBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(digests[AlgorithmMD5])
+		if err != nil {
+			return "", err
+		}
+		return string(decoded), nil
+	}
+
+	return digests[AlgorithmMD5], nil
+}
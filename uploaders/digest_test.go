@@ -0,0 +1,212 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, content string) *os.File {
+	t.Helper()
+
+	f, err := ioutil.TempFile("", "digest-test")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	t.Cleanup(func() {
+		f.Close()
+		os.Remove(f.Name())
+	})
+
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("failed to rewind temp file: %v", err)
+	}
+
+	return f
+}
+
+func TestParseChecksumAlgorithms(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty value", value: "", want: nil},
+		{name: "single algorithm", value: "md5", want: []string{AlgorithmMD5}},
+		{name: "multiple algorithms with spaces", value: "md5, sha256 ,crc32c", want: []string{AlgorithmMD5, AlgorithmSHA256, AlgorithmCRC32C}},
+		{name: "unsupported algorithm", value: "md5,rot13", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksumAlgorithms(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseChecksumAlgorithms() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseChecksumAlgorithms()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComputeDigestsResetsFileOffset(t *testing.T) {
+	f := writeTempFile(t, "hello world")
+
+	digests, err := ComputeDigests(f, []string{AlgorithmMD5, AlgorithmSHA256, AlgorithmCRC32C})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, algo := range []string{AlgorithmMD5, AlgorithmSHA256, AlgorithmCRC32C} {
+		if digests[algo] == "" {
+			t.Errorf("expected a digest for %s, got none", algo)
+		}
+	}
+
+	offset, err := f.Seek(0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if offset != 0 {
+		t.Errorf("expected file offset to be reset to 0, got %d", offset)
+	}
+}
+
+// TestHTTPUploaderSinglePUTHonorsConfiguredChecksumAlgorithms drives the single-PUT upload path
+// end-to-end against an httptest server, checking that https.checksum.algorithms selects which
+// digests are sent rather than always falling back to Content-MD5.
+func TestHTTPUploaderSinglePUTHonorsConfiguredChecksumAlgorithms(t *testing.T) {
+	var gotMD5, gotDigest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMD5 = r.Header.Get(ContentMD5)
+		gotDigest = r.Header.Get(ContentDigestHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	options := map[string]string{
+		URLProp:                server.URL + "/object",
+		ChecksumAlgorithmsProp: "sha256,crc32c",
+	}
+
+	uploader, err := NewHTTPUploader(options, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file := writeTempFile(t, "hello world")
+
+	if err := uploader.UploadFile(file, true, nil); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	if gotMD5 != "" {
+		t.Errorf("Content-MD5 = %q, want empty (md5 was not requested)", gotMD5)
+	}
+	if !strings.Contains(gotDigest, "sha-256=") || !strings.Contains(gotDigest, "crc32c=") {
+		t.Errorf("Content-Digest = %q, want entries for sha-256 and crc32c", gotDigest)
+	}
+}
+
+// TestHTTPUploaderMultipartHonorsConfiguredChecksumAlgorithms is the same check for the
+// multipart-part path, which has its own checksum wiring separate from the single-PUT path.
+func TestHTTPUploaderMultipartHonorsConfiguredChecksumAlgorithms(t *testing.T) {
+	const uploadID = "test-upload-id"
+
+	var mu sync.Mutex
+	var gotMD5, gotDigest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		switch r.Method {
+		case http.MethodPost:
+			if _, ok := query["uploads"]; ok {
+				fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, uploadID)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case http.MethodPut:
+			mu.Lock()
+			gotMD5 = r.Header.Get(ContentMD5)
+			gotDigest = r.Header.Get(ContentDigestHeader)
+			mu.Unlock()
+			w.Header().Set("ETag", "\"etag-1\"")
+		}
+	}))
+	defer server.Close()
+
+	options := map[string]string{
+		URLProp:                server.URL + "/object",
+		MultipartPartSizeProp:  "4",
+		ChecksumAlgorithmsProp: "sha256,crc32c",
+	}
+
+	uploader, err := NewHTTPUploader(options, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file := writeTempFile(t, "hello world")
+
+	if err := uploader.UploadFile(file, true, nil); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if gotMD5 != "" {
+		t.Errorf("Content-MD5 = %q, want empty (md5 was not requested)", gotMD5)
+	}
+	if !strings.Contains(gotDigest, "sha-256=") || !strings.Contains(gotDigest, "crc32c=") {
+		t.Errorf("Content-Digest = %q, want entries for sha-256 and crc32c", gotDigest)
+	}
+}
+
+func TestBuildContentDigestHeader(t *testing.T) {
+	digests := map[string]string{
+		AlgorithmMD5:    "bNNVbesNpUvKBgtMOUeYOQ==",
+		AlgorithmSHA256: "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=",
+	}
+
+	got := BuildContentDigestHeader(digests, []string{AlgorithmSHA256, AlgorithmMD5})
+	want := "sha-256=:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=:, md5=:bNNVbesNpUvKBgtMOUeYOQ==:"
+	if got != want {
+		t.Errorf("BuildContentDigestHeader() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// StorageProviderGCS identifies the Google Cloud Storage uploader in the storage.provider option
+const StorageProviderGCS = "gcs"
+
+// Constants for the GCS uploader 'start' operation options
+const (
+	GCSBucketProp      = "gcs.bucket"
+	GCSObjectProp      = "gcs.object"
+	GCSAccessTokenProp = "gcs.accessToken"
+	GCSEndpointProp    = "gcs.endpoint"
+
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+)
+
+// GCSUploader uploads files to Google Cloud Storage using the resumable upload protocol
+type GCSUploader struct {
+	endpoint    string
+	bucket      string
+	object      string
+	accessToken string
+	serverCert  string
+	tls         tlsOptions
+}
+
+// NewGCSUploader constructs a GCSUploader from the provided 'start' operation options
+func NewGCSUploader(options map[string]string, serverCert string) (Uploader, error) {
+	bucket := options[GCSBucketProp]
+	if bucket == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, GCSBucketProp)
+	}
+
+	object := options[GCSObjectProp]
+	if object == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, GCSObjectProp)
+	}
+
+	endpoint := options[GCSEndpointProp]
+	if endpoint == "" {
+		endpoint = defaultGCSEndpoint
+	}
+
+	tlsOpts, err := parseTLSOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GCSUploader{
+		endpoint:    endpoint,
+		bucket:      bucket,
+		object:      object,
+		accessToken: options[GCSAccessTokenProp],
+		serverCert:  serverCert,
+		tls:         tlsOpts,
+	}, nil
+}
+
+func (u *GCSUploader) httpClient() (*http.Client, error) {
+	transport, err := u.tls.buildTransport(u.serverCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// UploadFile starts a resumable session with GCS and uploads the file in a single session PUT
+func (u *GCSUploader) UploadFile(file *os.File, useChecksum bool, listener func(bytesTransferred int64)) error {
+	stats, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	client, err := u.httpClient()
+	if err != nil {
+		return err
+	}
+
+	sessionURI, err := u.startResumableSession(client, stats.Size())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, sessionURI, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stats.Size()
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", stats.Size()-1, stats.Size()))
+
+	if useChecksum {
+		md5, err := ComputeMD5(file, true)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(ContentMD5, md5)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("upload failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	if listener != nil {
+		listener(stats.Size())
+	}
+
+	return nil
+}
+
+func (u *GCSUploader) startResumableSession(client *http.Client, size int64) (string, error) {
+	initURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=resumable&name=%s",
+		u.endpoint, url.PathEscape(u.bucket), url.QueryEscape(u.object))
+
+	req, err := http.NewRequest(http.MethodPost, initURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	if u.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+u.accessToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("starting resumable session failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	sessionURI := resp.Header.Get("Location")
+	if sessionURI == "" {
+		return "", errors.New("resumable session response did not contain a Location header")
+	}
+
+	return sessionURI, nil
+}
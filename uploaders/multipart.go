@@ -0,0 +1,319 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	defaultMultipartConcurrency = 1
+	maxPartUploadAttempts       = 3
+
+	amzTrailerHeader         = "x-amz-trailer"
+	amzChecksumSHA256Trailer = "x-amz-checksum-sha256"
+	amzChecksumSHA256Header  = "X-Amz-Checksum-Sha256"
+)
+
+func parseMultipartPartSize(options map[string]string) (int64, error) {
+	v, ok := options[MultipartPartSizeProp]
+	if !ok {
+		return 0, nil
+	}
+
+	partSize, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || partSize <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", MultipartPartSizeProp, v)
+	}
+
+	return partSize, nil
+}
+
+func parseMultipartConcurrency(options map[string]string) (int, error) {
+	v, ok := options[MultipartConcurrencyProp]
+	if !ok {
+		return defaultMultipartConcurrency, nil
+	}
+
+	concurrency, err := strconv.Atoi(v)
+	if err != nil || concurrency <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", MultipartConcurrencyProp, v)
+	}
+
+	return concurrency, nil
+}
+
+// uploadMultipart uploads the file as a sequence of fixed-size parts, transferred concurrently
+// through a worker pool with retries, then completes the upload with a list of the part ETags.
+func (u *HTTPUploader) uploadMultipart(client *http.Client, file *os.File, size int64, useChecksum bool, listener func(bytesTransferred int64)) error {
+	uploadID, err := u.initiateMultipart(client)
+	if err != nil {
+		return err
+	}
+
+	numParts := int((size + u.multipartPartSize - 1) / u.multipartPartSize)
+	parts := make([]completedPart, numParts)
+
+	partNumbers := make(chan int)
+	var wg sync.WaitGroup
+
+	var mu sync.Mutex
+	var transferred int64
+	var firstErr error
+
+	concurrency := u.multipartConcurrency
+	if concurrency > numParts {
+		concurrency = numParts
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partNumber := range partNumbers {
+				n, etag, err := u.uploadPartWithRetries(client, file, uploadID, partNumber, size, useChecksum)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					parts[partNumber-1] = completedPart{PartNumber: partNumber, ETag: etag}
+					transferred += n
+					if listener != nil {
+						listener(transferred)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for partNumber := 1; partNumber <= numParts; partNumber++ {
+		partNumbers <- partNumber
+	}
+	close(partNumbers)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return u.completeMultipart(client, uploadID, parts)
+}
+
+func (u *HTTPUploader) uploadPartWithRetries(client *http.Client, file *os.File, uploadID string, partNumber int, size int64, useChecksum bool) (int64, string, error) {
+	offset := int64(partNumber-1) * u.multipartPartSize
+	length := u.multipartPartSize
+	if offset+length > size {
+		length = size - offset
+	}
+
+	buf := make([]byte, length)
+	if _, err := file.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return 0, "", err
+	}
+
+	var etag string
+	var err error
+	for attempt := 1; attempt <= maxPartUploadAttempts; attempt++ {
+		etag, err = u.uploadPart(client, uploadID, partNumber, buf, useChecksum)
+		if err == nil {
+			return length, etag, nil
+		}
+	}
+
+	return 0, "", fmt.Errorf("part %d failed after %d attempts: %v", partNumber, maxPartUploadAttempts, err)
+}
+
+func (u *HTTPUploader) uploadPart(client *http.Client, uploadID string, partNumber int, part []byte, useChecksum bool) (string, error) {
+	url := AppendQueryParams(u.url, fmt.Sprintf("partNumber=%d&uploadId=%s", partNumber, uploadID))
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(part))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(part))
+
+	for name, value := range u.headers {
+		req.Header.Set(name, value)
+	}
+
+	if useChecksum {
+		algorithms := u.checksumAlgorithms
+		if len(algorithms) == 0 {
+			algorithms = []string{AlgorithmMD5}
+		}
+
+		digests, err := ComputeDigestsBytes(part, algorithms)
+		if err != nil {
+			return "", err
+		}
+
+		if md5, ok := digests[AlgorithmMD5]; ok {
+			req.Header.Set(ContentMD5, md5)
+		}
+		if header := BuildContentDigestHeader(digests, algorithms); header != "" {
+			req.Header.Set(ContentDigestHeader, header)
+		}
+	}
+
+	if u.multipartTrailer {
+		// net/http only sends trailers over chunked transfer encoding, which requires an unset
+		// Content-Length; a fixed length here would silently drop the checksum trailer.
+		req.ContentLength = -1
+		req.Header.Set(amzTrailerHeader, amzChecksumSHA256Trailer)
+		req.Trailer = http.Header{amzChecksumSHA256Header: nil}
+
+		h := sha256.New()
+		req.Body = ioutil.NopCloser(&sha256TrailerReader{r: io.TeeReader(bytes.NewReader(part), h), h: h, req: req})
+	}
+
+	if u.awsSigV4 {
+		payloadHash := unsignedPayload
+		if !u.awsUnsignedPayload && !u.multipartTrailer {
+			payloadHash = hashHex(string(part))
+		}
+		if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+			return "", err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("upload part %d failed - code: %d, status: %s", partNumber, resp.StatusCode, resp.Status)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("upload part %d response did not contain an ETag", partNumber)
+	}
+
+	return etag, nil
+}
+
+func (u *HTTPUploader) initiateMultipart(client *http.Client) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, AppendQueryParams(u.url, "uploads"), nil)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range u.headers {
+		req.Header.Set(name, value)
+	}
+
+	if u.awsSigV4 {
+		payloadHash := unsignedPayload
+		if !u.awsUnsignedPayload {
+			payloadHash = hashHex("")
+		}
+		if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+			return "", err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("initiate multipart upload failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result initiateMultipartUploadResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("initiate multipart upload response did not contain an UploadId")
+	}
+
+	return result.UploadID, nil
+}
+
+func (u *HTTPUploader) completeMultipart(client *http.Client, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, AppendQueryParams(u.url, "uploadId="+uploadID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	for name, value := range u.headers {
+		req.Header.Set(name, value)
+	}
+
+	if u.awsSigV4 {
+		payloadHash := unsignedPayload
+		if !u.awsUnsignedPayload {
+			payloadHash = hashHex(string(body))
+		}
+		if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+			return err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("complete multipart upload failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
+
+// sha256TrailerReader streams a part through a SHA-256 hash and, once fully read, sets the
+// computed digest on the owning request's trailer so it can be sent as an x-amz-trailer.
+type sha256TrailerReader struct {
+	r   io.Reader
+	h   hash.Hash
+	req *http.Request
+}
+
+func (t *sha256TrailerReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err == io.EOF {
+		t.req.Trailer.Set(amzChecksumSHA256Header, base64.StdEncoding.EncodeToString(t.h.Sum(nil)))
+	}
+	return n, err
+}
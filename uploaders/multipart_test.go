@@ -0,0 +1,218 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestParseMultipartPartSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    int64
+		wantErr bool
+	}{
+		{name: "unset defaults to 0 (disabled)", set: false, want: 0},
+		{name: "positive value", set: true, value: "1048576", want: 1048576},
+		{name: "zero is rejected", set: true, value: "0", wantErr: true},
+		{name: "negative is rejected", set: true, value: "-1", wantErr: true},
+		{name: "not a number is rejected", set: true, value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := map[string]string{}
+			if tt.set {
+				options[MultipartPartSizeProp] = tt.value
+			}
+
+			got, err := parseMultipartPartSize(options)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseMultipartPartSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHTTPUploaderUploadMultipartConcurrencyAndRetry drives HTTPUploader.uploadMultipart's
+// worker-pool end-to-end against an httptest server: parts are transferred concurrently, a
+// transient failure on one part is retried and recovers, and the final complete request carries
+// every part in order. The target URL also already carries a query parameter, to confirm it
+// survives the "?uploads"/"?partNumber=.../"?uploadId=..." requests rather than being corrupted
+// by a second "?".
+func TestHTTPUploaderUploadMultipartConcurrencyAndRetry(t *testing.T) {
+	const uploadID = "test-upload-id"
+
+	var mu sync.Mutex
+	var received []completedPart
+	var receivedBytes []byte
+	attempts := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("token") != "abc" {
+			t.Errorf("token query param = %q, want %q (pre-existing query string was not preserved)", query.Get("token"), "abc")
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			if _, ok := query["uploads"]; ok {
+				fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, uploadID)
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error reading complete body: %v", err)
+				return
+			}
+
+			var complete completeMultipartUpload
+			if err := xml.Unmarshal(body, &complete); err != nil {
+				t.Errorf("unexpected error unmarshalling complete body: %v", err)
+				return
+			}
+
+			mu.Lock()
+			received = complete.Parts
+			mu.Unlock()
+
+		case http.MethodPut:
+			partNumber := query.Get("partNumber")
+
+			mu.Lock()
+			attempts[partNumber]++
+			attempt := attempts[partNumber]
+			mu.Unlock()
+
+			if partNumber == "3" && attempt == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error reading part body: %v", err)
+				return
+			}
+
+			mu.Lock()
+			receivedBytes = append(receivedBytes, body...)
+			mu.Unlock()
+
+			w.Header().Set("ETag", fmt.Sprintf("\"etag-%s\"", partNumber))
+
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	options := map[string]string{
+		URLProp:                  server.URL + "/object?token=abc",
+		MultipartPartSizeProp:    "2",
+		MultipartConcurrencyProp: "3",
+	}
+
+	uploader, err := NewHTTPUploader(options, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content := "0123456789"
+	file := writeTempFile(t, content)
+
+	if err := uploader.UploadFile(file, false, nil); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if attempts["3"] != 2 {
+		t.Errorf("part 3 was attempted %d times, want 2 (1 failure + 1 retry)", attempts["3"])
+	}
+
+	if len(receivedBytes) != len(content) {
+		t.Fatalf("server received %d bytes, want %d", len(receivedBytes), len(content))
+	}
+
+	if len(received) != 5 {
+		t.Fatalf("completed %d parts, want 5", len(received))
+	}
+	for i, part := range received {
+		wantNumber := i + 1
+		if part.PartNumber != wantNumber {
+			t.Errorf("part[%d].PartNumber = %d, want %d", i, part.PartNumber, wantNumber)
+		}
+		wantETag := fmt.Sprintf("\"etag-%d\"", wantNumber)
+		if part.ETag != wantETag {
+			t.Errorf("part[%d].ETag = %q, want %q", i, part.ETag, wantETag)
+		}
+	}
+}
+
+func TestParseMultipartConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    int
+		wantErr bool
+	}{
+		{name: "unset defaults to 1", set: false, want: defaultMultipartConcurrency},
+		{name: "positive value", set: true, value: "4", want: 4},
+		{name: "zero is rejected", set: true, value: "0", wantErr: true},
+		{name: "negative is rejected", set: true, value: "-1", wantErr: true},
+		{name: "not a number is rejected", set: true, value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := map[string]string{}
+			if tt.set {
+				options[MultipartConcurrencyProp] = tt.value
+			}
+
+			got, err := parseMultipartConcurrency(options)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseMultipartConcurrency() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
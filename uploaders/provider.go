@@ -0,0 +1,42 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import "fmt"
+
+// uploaderFactory creates an Uploader from the given 'start' operation options and server certificate
+type uploaderFactory func(options map[string]string, serverCert string) (Uploader, error)
+
+// providers holds the registered uploader factories keyed by the 'storage.provider' option value.
+// HTTPUploader is registered under both the empty string (the default) and StorageProviderHTTP,
+// so existing configurations that do not set storage.provider keep working unchanged.
+var providers = map[string]uploaderFactory{
+	"":                   NewHTTPUploader,
+	StorageProviderHTTP:  NewHTTPUploader,
+	StorageProviderS3:    NewS3Uploader,
+	StorageProviderGCS:   NewGCSUploader,
+	StorageProviderAzure: NewAzureUploader,
+}
+
+// NewUploader constructs the Uploader registered for the 'storage.provider' option, dispatching
+// to the provider-specific constructor at runtime. When the option is not set, the generic
+// HTTPUploader is used for backwards compatibility.
+func NewUploader(options map[string]string, serverCert string) (Uploader, error) {
+	provider := options[StorageProviderProp]
+
+	factory, ok := providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("unsupported storage provider: %s", provider)
+	}
+
+	return factory(options, serverCert)
+}
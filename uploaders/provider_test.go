@@ -0,0 +1,114 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import "testing"
+
+func TestNewUploaderDispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		options  map[string]string
+		wantType interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "default provider is generic HTTP",
+			provider: "",
+			options:  map[string]string{URLProp: "https://example.com/upload"},
+			wantType: &HTTPUploader{},
+		},
+		{
+			name:     "explicit generic provider",
+			provider: StorageProviderHTTP,
+			options:  map[string]string{URLProp: "https://example.com/upload"},
+			wantType: &HTTPUploader{},
+		},
+		{
+			name:     "s3 provider",
+			provider: StorageProviderS3,
+			options: map[string]string{
+				S3BucketProp:    "bucket",
+				S3RegionProp:    "eu-west-1",
+				S3KeyProp:       "key",
+				S3AccessKeyProp: "access",
+				S3SecretKeyProp: "secret",
+			},
+			wantType: &S3Uploader{},
+		},
+		{
+			name:     "gcs provider",
+			provider: StorageProviderGCS,
+			options: map[string]string{
+				GCSBucketProp: "bucket",
+				GCSObjectProp: "object",
+			},
+			wantType: &GCSUploader{},
+		},
+		{
+			name:     "azure provider",
+			provider: StorageProviderAzure,
+			options: map[string]string{
+				AzureAccountProp:   "account",
+				AzureContainerProp: "container",
+				AzureBlobProp:      "blob",
+			},
+			wantType: &AzureUploader{},
+		},
+		{
+			name:     "unknown provider is rejected",
+			provider: "unknown",
+			options:  map[string]string{},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := map[string]string{}
+			for k, v := range tt.options {
+				options[k] = v
+			}
+			options[StorageProviderProp] = tt.provider
+
+			uploader, err := NewUploader(options, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			switch tt.wantType.(type) {
+			case *HTTPUploader:
+				if _, ok := uploader.(*HTTPUploader); !ok {
+					t.Fatalf("expected *HTTPUploader, got %T", uploader)
+				}
+			case *S3Uploader:
+				if _, ok := uploader.(*S3Uploader); !ok {
+					t.Fatalf("expected *S3Uploader, got %T", uploader)
+				}
+			case *GCSUploader:
+				if _, ok := uploader.(*GCSUploader); !ok {
+					t.Fatalf("expected *GCSUploader, got %T", uploader)
+				}
+			case *AzureUploader:
+				if _, ok := uploader.(*AzureUploader); !ok {
+					t.Fatalf("expected *AzureUploader, got %T", uploader)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// resumeHashPrefixSize is the number of leading file bytes hashed to recognize a file across
+	// process restarts, without re-reading the whole (possibly large) file up front
+	resumeHashPrefixSize = 64 * 1024
+
+	defaultResumeChunkSize = 4 * 1024 * 1024
+)
+
+// resumeState is the progress persisted to https.resume.stateDir so an interrupted upload can
+// continue from where it left off, rather than restarting from byte 0
+type resumeState struct {
+	URL        string `json:"url"`
+	SessionURI string `json:"sessionURI"`
+	Offset     int64  `json:"offset"`
+	Size       int64  `json:"size"`
+	FileHash   string `json:"fileHash"`
+}
+
+// uploadResumable negotiates or resumes a session for the file and uploads it in Content-Range
+// chunks, persisting progress to u.resumeStateDir after each chunk so the upload survives
+// process restarts and transient network failures. Chunks are signed and checksummed the same
+// way as the single-PUT and multipart paths, so https.auth=aws-sigv4 and the checksum options
+// apply here too.
+func (u *HTTPUploader) uploadResumable(client *http.Client, file *os.File, size int64, useChecksum bool, listener func(bytesTransferred int64)) error {
+	fileHash, err := computeResumeFileHash(file)
+	if err != nil {
+		return err
+	}
+
+	statePath := u.resumeStatePath()
+
+	state, err := loadResumeState(statePath)
+	if err != nil {
+		return err
+	}
+
+	if state == nil || state.URL != u.url || state.Size != size || state.FileHash != fileHash {
+		sessionURI, offset, err := u.negotiateResumeSession(client, size)
+		if err != nil {
+			return err
+		}
+		state = &resumeState{URL: u.url, SessionURI: sessionURI, Offset: offset, Size: size, FileHash: fileHash}
+		if err := saveResumeState(statePath, state); err != nil {
+			return err
+		}
+	}
+
+	chunkSize := u.multipartPartSize
+	if chunkSize <= 0 {
+		chunkSize = defaultResumeChunkSize
+	}
+
+	for state.Offset < size {
+		end := state.Offset + chunkSize
+		if end > size {
+			end = size
+		}
+
+		if err := u.uploadResumeChunk(client, file, state, end, useChecksum); err != nil {
+			return err
+		}
+
+		if err := saveResumeState(statePath, state); err != nil {
+			return err
+		}
+
+		if listener != nil {
+			listener(state.Offset)
+		}
+	}
+
+	return os.Remove(statePath)
+}
+
+func (u *HTTPUploader) uploadResumeChunk(client *http.Client, file *os.File, state *resumeState, end int64, useChecksum bool) error {
+	length := end - state.Offset
+
+	buf := make([]byte, length)
+	if _, err := file.ReadAt(buf, state.Offset); err != nil && err != io.EOF {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, state.SessionURI, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = length
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", state.Offset, end-1, state.Size))
+
+	if useChecksum {
+		algorithms := u.checksumAlgorithms
+		if len(algorithms) == 0 {
+			algorithms = []string{AlgorithmMD5}
+		}
+
+		digests, err := ComputeDigestsBytes(buf, algorithms)
+		if err != nil {
+			return err
+		}
+
+		if md5, ok := digests[AlgorithmMD5]; ok {
+			req.Header.Set(ContentMD5, md5)
+		}
+		if header := BuildContentDigestHeader(digests, algorithms); header != "" {
+			req.Header.Set(ContentDigestHeader, header)
+		}
+	}
+
+	if u.awsSigV4 {
+		payloadHash := unsignedPayload
+		if !u.awsUnsignedPayload {
+			payloadHash = hashHex(string(buf))
+		}
+		if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+			return err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("upload chunk %d-%d failed - code: %d, status: %s", state.Offset, end-1, resp.StatusCode, resp.Status)
+	}
+
+	state.Offset = end
+
+	return nil
+}
+
+// negotiateResumeSession starts a new upload session, either via the configured init URL or, if
+// none is set, by probing the target URL itself with a Content-Range "bytes */<size>" request to
+// discover any offset the server already committed, and returns the session URI to upload to
+// together with the offset to resume from.
+func (u *HTTPUploader) negotiateResumeSession(client *http.Client, size int64) (string, int64, error) {
+	if u.resumeInitURL != "" {
+		req, err := http.NewRequest(http.MethodPost, u.resumeInitURL, nil)
+		if err != nil {
+			return "", 0, err
+		}
+		req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+		if u.awsSigV4 {
+			payloadHash := unsignedPayload
+			if !u.awsUnsignedPayload {
+				payloadHash = hashHex("")
+			}
+			if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+				return "", 0, err
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", 0, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return "", 0, fmt.Errorf("resume session init failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+		}
+
+		if sessionURI := resp.Header.Get("Location"); sessionURI != "" {
+			return sessionURI, 0, nil
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", 0, err
+		}
+		return strings.TrimSpace(string(body)), 0, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u.url, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+
+	if u.awsSigV4 {
+		payloadHash := unsignedPayload
+		if !u.awsUnsignedPayload {
+			payloadHash = hashHex("")
+		}
+		if err := signAWSRequestV4(req, u.awsAccessKey, u.awsSecretKey, u.awsRegion, u.awsService, payloadHash); err != nil {
+			return "", 0, err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && resp.StatusCode != http.StatusPermanentRedirect {
+		return "", 0, fmt.Errorf("resume offset probe failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	return u.url, parseResumeOffset(resp), nil
+}
+
+// parseResumeOffset reads the next byte the server expects from a probe response's Range header
+// (e.g. "bytes=0-12345" means 12346 bytes were already committed). Returns 0 when absent.
+func parseResumeOffset(resp *http.Response) int64 {
+	rng := resp.Header.Get("Range")
+	if rng == "" {
+		return 0
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(rng, "bytes="), "-", 2)
+	if len(parts) != 2 {
+		return 0
+	}
+
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return end + 1
+}
+
+func (u *HTTPUploader) resumeStatePath() string {
+	sum := sha256.Sum256([]byte(u.url))
+	return filepath.Join(u.resumeStateDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func computeResumeFileHash(file *os.File) (string, error) {
+	h := sha256.New()
+	if _, err := io.CopyN(h, file, resumeHashPrefixSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	file.Seek(0, 0)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func loadResumeState(path string) (*resumeState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var state resumeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func saveResumeState(path string, state *resumeState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// TestUploadResumableResumesAfterRestart drives uploadResumable end-to-end against an httptest
+// server: the first upload attempt fails partway through (after one chunk has been persisted),
+// and a fresh HTTPUploader - simulating a process restart - picks the persisted state back up,
+// skips renegotiating a session and finishes from where the first attempt left off.
+func TestUploadResumableResumesAfterRestart(t *testing.T) {
+	const content = "abcdefghi" // 9 bytes, 3-byte chunks => 3 chunks
+
+	stateDir, err := ioutil.TempDir("", "resume-test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	var mu sync.Mutex
+	var requestCount int
+	var receivedBytes []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		n := requestCount
+		mu.Unlock()
+
+		if r.Header.Get("Content-Range") == "bytes */9" {
+			// Offset probe: nothing committed yet.
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if n == 3 {
+			// Fail the first attempt at the second chunk, after the first chunk succeeded.
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("unexpected error reading chunk body: %v", err)
+			return
+		}
+
+		mu.Lock()
+		receivedBytes = append(receivedBytes, body...)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	options := map[string]string{
+		URLProp:               server.URL + "/object",
+		MultipartPartSizeProp: "3",
+		ResumeStateDirProp:    stateDir,
+	}
+
+	newUploader := func() Uploader {
+		uploader, err := NewHTTPUploader(options, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return uploader
+	}
+
+	openFile := func() *os.File {
+		f, err := ioutil.TempFile("", "resume-upload-file")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { os.Remove(f.Name()) })
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := f.Seek(0, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return f
+	}
+
+	file := openFile()
+	defer file.Close()
+
+	if err := newUploader().UploadFile(file, false, nil); err == nil {
+		t.Fatalf("expected the first attempt to fail, got no error")
+	}
+
+	// Simulate a process restart: a fresh uploader and a freshly reopened file, reusing the same
+	// persisted resume state on disk.
+	restarted, err := os.Open(file.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer restarted.Close()
+
+	if err := newUploader().UploadFile(restarted, false, nil); err != nil {
+		t.Fatalf("UploadFile() after restart error = %v", err)
+	}
+
+	if string(receivedBytes) != content {
+		t.Errorf("server received %q, want %q", receivedBytes, content)
+	}
+
+	statePath := newUploader().(*HTTPUploader).resumeStatePath()
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("expected resume state file to be removed after completion, stat err = %v", err)
+	}
+}
+
+func TestParseResumeOffset(t *testing.T) {
+	tests := []struct {
+		name string
+		rng  string
+		want int64
+	}{
+		{name: "no Range header", rng: "", want: 0},
+		{name: "single committed byte", rng: "bytes=0-0", want: 1},
+		{name: "partial upload committed", rng: "bytes=0-12345", want: 12346},
+		{name: "malformed header is ignored", rng: "bytes=garbage", want: 0},
+		{name: "missing end is ignored", rng: "bytes=0", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.rng != "" {
+				resp.Header.Set("Range", tt.rng)
+			}
+
+			got := parseResumeOffset(resp)
+			if got != tt.want {
+				t.Errorf("parseResumeOffset() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StorageProviderS3 identifies the Amazon S3 (or S3-compatible) uploader in the storage.provider option
+const StorageProviderS3 = "s3"
+
+// Constants for the S3 uploader 'start' operation options
+const (
+	S3BucketProp    = "s3.bucket"
+	S3RegionProp    = "s3.region"
+	S3EndpointProp  = "s3.endpoint"
+	S3KeyProp       = "s3.key"
+	S3PartSizeProp  = "s3.partSize"
+	S3AccessKeyProp = "s3.accessKey"
+	S3SecretKeyProp = "s3.secretKey"
+
+	defaultS3PartSize = 5 * 1024 * 1024
+
+	amzContentSHA256Header = "x-amz-content-sha256"
+)
+
+// completeMultipartUpload is the XML body sent to S3 to finish a multipart upload
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+// S3Uploader uploads files to Amazon S3 (or an S3-compatible endpoint) using multipart PUT requests
+type S3Uploader struct {
+	endpoint   string
+	bucket     string
+	region     string
+	key        string
+	accessKey  string
+	secretKey  string
+	partSize   int64
+	serverCert string
+	tls        tlsOptions
+}
+
+// NewS3Uploader constructs an S3Uploader from the provided 'start' operation options
+func NewS3Uploader(options map[string]string, serverCert string) (Uploader, error) {
+	bucket := options[S3BucketProp]
+	if bucket == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, S3BucketProp)
+	}
+
+	region := options[S3RegionProp]
+	if region == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, S3RegionProp)
+	}
+
+	key := options[S3KeyProp]
+	if key == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, S3KeyProp)
+	}
+
+	accessKey := options[S3AccessKeyProp]
+	if accessKey == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, S3AccessKeyProp)
+	}
+
+	secretKey := options[S3SecretKeyProp]
+	if secretKey == "" {
+		return nil, fmt.Errorf(missingParameterErrMsg, S3SecretKeyProp)
+	}
+
+	endpoint := options[S3EndpointProp]
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	}
+
+	partSize := int64(defaultS3PartSize)
+	if v, ok := options[S3PartSizeProp]; ok {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s: %s", S3PartSizeProp, v)
+		}
+		partSize = parsed
+	}
+
+	tlsOpts, err := parseTLSOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Uploader{
+		endpoint:   endpoint,
+		bucket:     bucket,
+		region:     region,
+		key:        key,
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		partSize:   partSize,
+		serverCert: serverCert,
+		tls:        tlsOpts,
+	}, nil
+}
+
+func (u *S3Uploader) httpClient() (*http.Client, error) {
+	if !strings.HasPrefix(u.endpoint, "https") {
+		return &http.Client{Transport: &http.Transport{}}, nil
+	}
+
+	transport, err := u.tls.buildTransport(u.serverCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// UploadFile uploads the file to S3 as a sequence of multipart parts, then completes the upload
+func (u *S3Uploader) UploadFile(file *os.File, useChecksum bool, listener func(bytesTransferred int64)) error {
+	stats, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	client, err := u.httpClient()
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := u.initiateUpload(client)
+	if err != nil {
+		return err
+	}
+
+	var parts []completedPart
+	var transferred int64
+	partNumber := 1
+	buf := make([]byte, u.partSize)
+
+	for {
+		n, readErr := io.ReadFull(file, buf)
+		if n == 0 {
+			break
+		}
+
+		etag, err := u.uploadPart(client, uploadID, partNumber, buf[:n], useChecksum)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+
+		transferred += int64(n)
+		if listener != nil {
+			listener(transferred)
+		}
+		partNumber++
+
+		if readErr == io.ErrUnexpectedEOF || readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if transferred != stats.Size() {
+		return fmt.Errorf("uploaded %d of %d bytes", transferred, stats.Size())
+	}
+
+	return u.completeUpload(client, uploadID, parts)
+}
+
+func (u *S3Uploader) objectURL() string {
+	return fmt.Sprintf("%s/%s", strings.TrimRight(u.endpoint, "/"), EscapePathSegments(u.key))
+}
+
+func (u *S3Uploader) initiateUpload(client *http.Client) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, u.objectURL()+"?uploads", nil)
+	if err != nil {
+		return "", err
+	}
+
+	if err := signAWSRequestV4(req, u.accessKey, u.secretKey, u.region, defaultAWSService, hashHex("")); err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("initiate multipart upload failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result initiateMultipartUploadResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("initiate multipart upload response did not contain an UploadId")
+	}
+
+	return result.UploadID, nil
+}
+
+func (u *S3Uploader) uploadPart(client *http.Client, uploadID string, partNumber int, part []byte, useChecksum bool) (string, error) {
+	url := fmt.Sprintf("%s?partNumber=%d&uploadId=%s", u.objectURL(), partNumber, uploadID)
+
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(string(part)))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(part))
+
+	if useChecksum {
+		sum := md5.Sum(part)
+		req.Header.Set(ContentMD5, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	if err := signAWSRequestV4(req, u.accessKey, u.secretKey, u.region, defaultAWSService, hashHex(string(part))); err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("upload part %d failed - code: %d, status: %s", partNumber, resp.StatusCode, resp.Status)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("upload part %d response did not contain an ETag", partNumber)
+	}
+
+	return etag, nil
+}
+
+func (u *S3Uploader) completeUpload(client *http.Client, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s?uploadId=%s", u.objectURL(), uploadID)
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+
+	if err := signAWSRequestV4(req, u.accessKey, u.secretKey, u.region, defaultAWSService, hashHex(string(body))); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("complete multipart upload failed - code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func baseS3Options() map[string]string {
+	return map[string]string{
+		S3BucketProp:    "bucket",
+		S3RegionProp:    "eu-west-1",
+		S3KeyProp:       "key",
+		S3AccessKeyProp: "access",
+		S3SecretKeyProp: "secret",
+	}
+}
+
+func TestNewS3UploaderPartSizeValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		partSize string
+		wantErr  bool
+	}{
+		{name: "unset uses default", partSize: ""},
+		{name: "positive value", partSize: "1048576"},
+		{name: "zero is rejected", partSize: "0", wantErr: true},
+		{name: "negative is rejected", partSize: "-1", wantErr: true},
+		{name: "not a number is rejected", partSize: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := baseS3Options()
+			if tt.partSize != "" {
+				options[S3PartSizeProp] = tt.partSize
+			}
+
+			_, err := NewS3Uploader(options, "")
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestS3UploaderUploadFileMultipartSequence drives S3Uploader.UploadFile's full
+// initiate/uploadPart/complete sequence against an httptest server, verifying the parts are
+// transferred completely, checksummed and reassembled in the correct order server-side.
+func TestS3UploaderUploadFileMultipartSequence(t *testing.T) {
+	const uploadID = "test-upload-id"
+
+	var mu sync.Mutex
+	var received []completedPart
+	var receivedBytes []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		switch r.Method {
+		case http.MethodPost:
+			if _, ok := query["uploads"]; ok {
+				fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, uploadID)
+				return
+			}
+
+			if query.Get("uploadId") != uploadID {
+				t.Errorf("complete request uploadId = %q, want %q", query.Get("uploadId"), uploadID)
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error reading complete body: %v", err)
+				return
+			}
+
+			var complete completeMultipartUpload
+			if err := xml.Unmarshal(body, &complete); err != nil {
+				t.Errorf("unexpected error unmarshalling complete body: %v", err)
+				return
+			}
+
+			mu.Lock()
+			received = complete.Parts
+			mu.Unlock()
+
+		case http.MethodPut:
+			if query.Get("uploadId") != uploadID {
+				t.Errorf("uploadPart uploadId = %q, want %q", query.Get("uploadId"), uploadID)
+			}
+			if r.Header.Get(ContentMD5) == "" {
+				t.Errorf("uploadPart request missing Content-MD5 header")
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error reading part body: %v", err)
+				return
+			}
+
+			mu.Lock()
+			receivedBytes = append(receivedBytes, body...)
+			mu.Unlock()
+
+			w.Header().Set("ETag", fmt.Sprintf("\"etag-%s\"", query.Get("partNumber")))
+
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	options := baseS3Options()
+	options[S3EndpointProp] = server.URL
+	options[S3PartSizeProp] = "4"
+
+	uploader, err := NewS3Uploader(options, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content := "0123456789"
+	file := writeTempFile(t, content)
+
+	if err := uploader.UploadFile(file, true, nil); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	if string(receivedBytes) != content {
+		t.Errorf("server received bytes %q, want %q", receivedBytes, content)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("completed %d parts, want 3", len(received))
+	}
+	for i, part := range received {
+		wantNumber := i + 1
+		if part.PartNumber != wantNumber {
+			t.Errorf("part[%d].PartNumber = %d, want %d", i, part.PartNumber, wantNumber)
+		}
+		wantETag := fmt.Sprintf("\"etag-%d\"", wantNumber)
+		if part.ETag != wantETag {
+			t.Errorf("part[%d].ETag = %q, want %q", i, part.ETag, wantETag)
+		}
+	}
+}
+
+func TestS3ObjectURLEscapesKey(t *testing.T) {
+	options := baseS3Options()
+	options[S3KeyProp] = "path/to/object#1 two.txt"
+	options[S3EndpointProp] = "https://example.com"
+
+	uploader, err := NewS3Uploader(options, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := uploader.(*S3Uploader).objectURL()
+	want := "https://example.com/path/to/object%231%20two.txt"
+	if got != want {
+		t.Errorf("objectURL() = %q, want %q", got, want)
+	}
+}
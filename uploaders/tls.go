@@ -0,0 +1,189 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse-kanto/file-upload/logger"
+)
+
+// Constants for mutual TLS and TLS tuning options of the HTTPS uploader
+const (
+	TLSClientCertProp        = "https.tls.clientCert"
+	TLSClientKeyProp         = "https.tls.clientKey"
+	TLSClientKeyPasswordProp = "https.tls.clientKeyPassword"
+	TLSMinVersionProp        = "https.tls.minVersion"
+	TLSServerNameProp        = "https.tls.serverName"
+	TLSCipherSuitesProp      = "https.tls.cipherSuites"
+)
+
+// parseTLSMinVersion maps the https.tls.minVersion option to a crypto/tls version constant,
+// defaulting to TLS 1.2 to preserve the uploader's existing minimum.
+func parseTLSMinVersion(value string) (uint16, error) {
+	switch value {
+	case "":
+		return tls.VersionTLS12, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported %s: %s", TLSMinVersionProp, value)
+	}
+}
+
+// parseTLSCipherSuites resolves a comma-separated list of cipher suite names from
+// https.tls.cipherSuites, overriding SupportedCipherSuites() when set.
+func parseTLSCipherSuites(value string) ([]uint16, error) {
+	if value == "" {
+		return SupportedCipherSuites(), nil
+	}
+
+	available := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		available[cs.Name] = cs.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported %s: %s", TLSCipherSuitesProp, name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// tlsOptions bundles the https.tls.* options shared by every HTTPS-speaking uploader, so mTLS
+// and TLS tuning apply uniformly regardless of which storage.provider is selected.
+type tlsOptions struct {
+	cipherSuites      []uint16
+	minVersion        uint16
+	serverName        string
+	clientCertPath    string
+	clientKeyPath     string
+	clientKeyPassword string
+}
+
+// parseTLSOptions reads the https.tls.* options common to all uploaders
+func parseTLSOptions(options map[string]string) (tlsOptions, error) {
+	cipherSuites, err := parseTLSCipherSuites(options[TLSCipherSuitesProp])
+	if err != nil {
+		return tlsOptions{}, err
+	}
+
+	minVersion, err := parseTLSMinVersion(options[TLSMinVersionProp])
+	if err != nil {
+		return tlsOptions{}, err
+	}
+
+	return tlsOptions{
+		cipherSuites:      cipherSuites,
+		minVersion:        minVersion,
+		serverName:        options[TLSServerNameProp],
+		clientCertPath:    options[TLSClientCertProp],
+		clientKeyPath:     options[TLSClientKeyProp],
+		clientKeyPassword: options[TLSClientKeyPasswordProp],
+	}, nil
+}
+
+// buildTransport builds the *http.Transport for o, loading the given CA bundle and, when
+// configured, the mTLS client certificate. This is the single place all uploaders construct
+// their TLS transport from, so https.tls.* options behave the same for every storage.provider.
+func (o tlsOptions) buildTransport(serverCert string) (*http.Transport, error) {
+	var caCertPool *x509.CertPool
+	if len(serverCert) > 0 {
+		caCert, err := ioutil.ReadFile(serverCert)
+		if err != nil {
+			logger.Errorf("Error reading CA certificate file - \"%s\"", serverCert)
+			return nil, err
+		}
+		caCertPool = x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+	}
+
+	config := &tls.Config{ // using the system CA pool
+		InsecureSkipVerify: false,
+		RootCAs:            caCertPool,
+		MinVersion:         o.minVersion,
+		MaxVersion:         tls.VersionTLS13,
+		CipherSuites:       o.cipherSuites,
+		ServerName:         o.serverName,
+	}
+
+	if o.clientCertPath != "" {
+		cert, err := loadClientCertificate(o.clientCertPath, o.clientKeyPath, o.clientKeyPassword)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Transport{TLSClientConfig: config}, nil
+}
+
+// loadClientCertificate reads a PEM client certificate and (optionally password-encrypted) PEM
+// private key pair for mutual TLS authentication.
+func loadClientCertificate(certPath, keyPath, keyPassword string) (tls.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		logger.Errorf("Error reading client certificate file - \"%s\"", certPath)
+		return tls.Certificate{}, err
+	}
+
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		logger.Errorf("Error reading client key file - \"%s\"", keyPath)
+		return tls.Certificate{}, err
+	}
+
+	if keyPassword != "" {
+		keyPEM, err = decryptPEMKey(keyPEM, keyPassword)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func decryptPEMKey(keyPEM []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid client key PEM")
+	}
+
+	if !x509.IsEncryptedPEMBlock(block) { // nolint:staticcheck // no stdlib replacement for PEM-level decryption
+		return keyPEM, nil
+	}
+
+	decrypted, err := x509.DecryptPEMBlock(block, []byte(password)) // nolint:staticcheck
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: decrypted}), nil
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2021 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// http://www.eclipse.org/legal/epl-2.0
+//
+// SPDX-License-Identifier: EPL-2.0
+
+package uploaders
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// testCA is a minimal self-signed CA used to mint a server certificate (for the httptest.Server)
+// and a client certificate (for mTLS), so TestHTTPUploaderMutualTLS can drive a real TLS/mTLS
+// handshake end-to-end instead of only unit-testing option parsing.
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating CA certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error parsing CA certificate: %v", err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+// issue mints a leaf certificate signed by the CA, for use as either a server or a client
+// certificate depending on extKeyUsage.
+func (ca *testCA) issue(t *testing.T, commonName string, extKeyUsage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating leaf key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{extKeyUsage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("unexpected error creating leaf certificate: %v", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func writeTempPEM(t *testing.T, pemBytes []byte) string {
+	t.Helper()
+
+	f, err := ioutil.TempFile("", "tls-test-*.pem")
+	if err != nil {
+		t.Fatalf("unexpected error creating temp file: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	if _, err := f.Write(pemBytes); err != nil {
+		t.Fatalf("unexpected error writing temp file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error closing temp file: %v", err)
+	}
+
+	return f.Name()
+}
+
+// TestHTTPUploaderMutualTLS drives HTTPUploader.UploadFile against an httptest server requiring
+// client certificates, checking that https.tls.* options wire up a real mTLS handshake: a request
+// without a client certificate is rejected by the server, and one with a CA-issued client
+// certificate succeeds and is trusted via the configured CA bundle rather than the system pool.
+func TestHTTPUploaderMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	caCertPath := writeTempPEM(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+
+	serverCertPEM, serverKeyPEM := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	serverCert, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	if err != nil {
+		t.Fatalf("unexpected error building server certificate: %v", err)
+	}
+
+	clientCAPool := x509.NewCertPool()
+	clientCAPool.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAPool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	uploadOnce := func(options map[string]string) error {
+		uploader, err := NewHTTPUploader(options, caCertPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		file := writeTempFile(t, "hello world")
+		return uploader.UploadFile(file, false, nil)
+	}
+
+	t.Run("without a client certificate the server rejects the handshake", func(t *testing.T) {
+		err := uploadOnce(map[string]string{URLProp: server.URL + "/object"})
+		if err == nil {
+			t.Fatalf("expected an error, got none")
+		}
+	})
+
+	t.Run("with the CA-issued client certificate the upload succeeds", func(t *testing.T) {
+		clientCertPEM, clientKeyPEM := ca.issue(t, "test-client", x509.ExtKeyUsageClientAuth)
+		clientCertPath := writeTempPEM(t, clientCertPEM)
+		clientKeyPath := writeTempPEM(t, clientKeyPEM)
+
+		err := uploadOnce(map[string]string{
+			URLProp:           server.URL + "/object",
+			TLSClientCertProp: clientCertPath,
+			TLSClientKeyProp:  clientKeyPath,
+			TLSMinVersionProp: "1.3",
+		})
+		if err != nil {
+			t.Fatalf("UploadFile() error = %v", err)
+		}
+	})
+}
+
+func TestParseTLSMinVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    uint16
+		wantErr bool
+	}{
+		{name: "unset defaults to TLS 1.2", value: "", want: tls.VersionTLS12},
+		{name: "1.0", value: "1.0", want: tls.VersionTLS10},
+		{name: "1.1", value: "1.1", want: tls.VersionTLS11},
+		{name: "1.2", value: "1.2", want: tls.VersionTLS12},
+		{name: "1.3", value: "1.3", want: tls.VersionTLS13},
+		{name: "unsupported value is rejected", value: "0.9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTLSMinVersion(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseTLSMinVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTLSCipherSuites(t *testing.T) {
+	t.Run("unset falls back to SupportedCipherSuites", func(t *testing.T) {
+		got, err := parseTLSCipherSuites("")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := SupportedCipherSuites()
+		if len(got) != len(want) {
+			t.Fatalf("parseTLSCipherSuites(\"\") returned %d suites, want %d", len(got), len(want))
+		}
+	})
+
+	t.Run("known suite names resolve to their ids", func(t *testing.T) {
+		name := tls.CipherSuites()[0].Name
+
+		got, err := parseTLSCipherSuites(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0] != tls.CipherSuites()[0].ID {
+			t.Errorf("parseTLSCipherSuites(%q) = %v, want [%d]", name, got, tls.CipherSuites()[0].ID)
+		}
+	})
+
+	t.Run("unknown suite name is rejected", func(t *testing.T) {
+		if _, err := parseTLSCipherSuites("NOT_A_REAL_SUITE"); err == nil {
+			t.Fatalf("expected an error, got none")
+		}
+	})
+}